@@ -0,0 +1,273 @@
+package caddypsl
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/caddyserver/caddy/v2"
+	"go.uber.org/zap"
+	"golang.org/x/net/publicsuffix"
+)
+
+func init() {
+	caddy.RegisterModule(new(App))
+}
+
+// defaultPSLSource is the canonical, authoritative home of the Public Suffix List.
+const defaultPSLSource = "https://publicsuffix.org/list/public_suffix_list.dat"
+
+// App is a Caddy app (module ID "psl") that owns a live-updating Public Suffix List,
+// refreshed periodically from a URL or local file so operators don't need to recompile
+// just to pick up new PSL entries. Other modules in this package, such as Handler and
+// MatchPublicSuffix, look it up via `ctx.App("psl")` and fall back to the PSL compiled
+// into golang.org/x/net/publicsuffix if this app isn't configured at all.
+type App struct {
+	// Source is a URL or local file path to load the PSL from. If empty, it defaults to
+	// https://publicsuffix.org/list/public_suffix_list.dat.
+	Source string `json:"source,omitempty"`
+
+	// RefreshInterval is how often to reload Source, whether it's a URL or a local file.
+	// Default is 24h.
+	RefreshInterval caddy.Duration `json:"refresh_interval,omitempty"`
+
+	// Timeout is how long to wait for a single fetch of Source, if it's a URL. Default is 30s.
+	Timeout caddy.Duration `json:"timeout,omitempty"`
+
+	// ICANNOnly, if true, loads only the ICANN-managed section of the list, ignoring the
+	// private-domains section entirely.
+	ICANNOnly bool `json:"icann_only,omitempty"`
+
+	// OnError controls what happens if a refresh fails: "keep_stale" (default) keeps
+	// serving the last good list, "fallback" switches to the compiled-in PSL, and "fail"
+	// makes Provision return an error if the first load fails; a failed background refresh
+	// under "fail" is logged and otherwise handled like "keep_stale".
+	OnError string `json:"on_error,omitempty"`
+
+	logger     *zap.Logger
+	httpClient *http.Client
+
+	mu       sync.RWMutex
+	list     *pslTrie
+	version  string
+	etag     string
+	modified string
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// CaddyModule returns the Caddy module information.
+func (a *App) CaddyModule() caddy.ModuleInfo {
+	return caddy.ModuleInfo{
+		ID:  "psl",
+		New: func() caddy.Module { return new(App) },
+	}
+}
+
+// Provision sets up the app and performs the initial load of the list.
+func (a *App) Provision(ctx caddy.Context) error {
+	a.logger = ctx.Logger()
+
+	if a.Source == "" {
+		a.Source = defaultPSLSource
+	}
+	if a.RefreshInterval == 0 {
+		a.RefreshInterval = caddy.Duration(24 * time.Hour)
+	}
+	if a.Timeout == 0 {
+		a.Timeout = caddy.Duration(30 * time.Second)
+	}
+	switch a.OnError {
+	case "":
+		a.OnError = "keep_stale"
+	case "keep_stale", "fallback", "fail":
+	default:
+		return fmt.Errorf("on_error must be 'keep_stale', 'fallback', or 'fail', got %q", a.OnError)
+	}
+
+	a.httpClient = &http.Client{Timeout: time.Duration(a.Timeout)}
+	a.stop = make(chan struct{})
+	a.done = make(chan struct{})
+
+	if err := a.refresh(); err != nil {
+		if a.OnError == "fail" {
+			return fmt.Errorf("loading initial public suffix list: %v", err)
+		}
+		a.logger.Warn("initial public suffix list load failed, falling back to compiled-in list",
+			zap.String("source", a.Source),
+			zap.Error(err))
+	}
+
+	return nil
+}
+
+// Start begins the background refresh loop.
+func (a *App) Start() error {
+	go a.refreshLoop()
+	return nil
+}
+
+// Stop halts the background refresh loop.
+func (a *App) Stop() error {
+	close(a.stop)
+	<-a.done
+	return nil
+}
+
+func (a *App) refreshLoop() {
+	defer close(a.done)
+	ticker := time.NewTicker(time.Duration(a.RefreshInterval))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := a.refresh(); err != nil {
+				a.logger.Warn("public suffix list refresh failed",
+					zap.String("source", a.Source),
+					zap.Error(err))
+			}
+		case <-a.stop:
+			return
+		}
+	}
+}
+
+// refresh fetches and re-parses a.Source, atomically swapping in the new list on success.
+// A 304 Not Modified (when Source is a URL) is treated as success with no change.
+func (a *App) refresh() error {
+	if strings.HasPrefix(a.Source, "http://") || strings.HasPrefix(a.Source, "https://") {
+		return a.refreshFromURL()
+	}
+	return a.refreshFromFile()
+}
+
+func (a *App) refreshFromURL() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(a.Timeout))
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.Source, nil)
+	if err != nil {
+		return err
+	}
+
+	a.mu.RLock()
+	etag, modified := a.etag, a.modified
+	a.mu.RUnlock()
+
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if modified != "" {
+		req.Header.Set("If-Modified-Since", modified)
+	}
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return a.handleRefreshError(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return a.handleRefreshError(fmt.Errorf("unexpected status %s fetching %s", resp.Status, a.Source))
+	}
+
+	list, err := parsePSL(resp.Body, a.ICANNOnly)
+	if err != nil {
+		return a.handleRefreshError(err)
+	}
+
+	a.mu.Lock()
+	a.list = list
+	a.etag = resp.Header.Get("ETag")
+	a.modified = resp.Header.Get("Last-Modified")
+	a.version = versionFromHeaders(a.etag, a.modified)
+	a.mu.Unlock()
+
+	return nil
+}
+
+func (a *App) refreshFromFile() error {
+	f, err := os.Open(a.Source)
+	if err != nil {
+		return a.handleRefreshError(err)
+	}
+	defer f.Close()
+
+	list, err := parsePSL(f, a.ICANNOnly)
+	if err != nil {
+		return a.handleRefreshError(err)
+	}
+
+	info, statErr := f.Stat()
+
+	a.mu.Lock()
+	a.list = list
+	if statErr == nil {
+		a.version = "file:" + info.ModTime().UTC().Format(time.RFC3339)
+	}
+	a.mu.Unlock()
+
+	return nil
+}
+
+// handleRefreshError applies the OnError policy to a failed refresh. It returns err so the
+// caller can still log it, except that "fallback" clears the loaded list so Lookup falls back
+// to the compiled-in PSL immediately.
+func (a *App) handleRefreshError(err error) error {
+	if a.OnError == "fallback" {
+		a.mu.Lock()
+		a.list = nil
+		a.version = "compiled-in (fallback)"
+		a.mu.Unlock()
+	}
+	return err
+}
+
+func versionFromHeaders(etag, modified string) string {
+	switch {
+	case etag != "":
+		return etag
+	case modified != "":
+		return modified
+	default:
+		return "unknown-" + strconv.FormatInt(time.Now().Unix(), 10)
+	}
+}
+
+// Lookup returns the public suffix of domain and whether it's ICANN-managed, using the
+// live-loaded list if one is available, or else the PSL compiled into
+// golang.org/x/net/publicsuffix.
+func (a *App) Lookup(domain string) (suffix string, icann bool) {
+	a.mu.RLock()
+	list := a.list
+	a.mu.RUnlock()
+
+	if list == nil {
+		return publicsuffix.PublicSuffix(domain)
+	}
+	return list.Lookup(domain)
+}
+
+// Version returns an opaque identifier for the currently-loaded list snapshot, suitable for
+// the `psl.version` placeholder. It's empty until the first successful load, except that a
+// failed load under on_error "fallback" sets it to "compiled-in (fallback)".
+func (a *App) Version() string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.version
+}
+
+// Interface guards
+var (
+	_ caddy.App         = (*App)(nil)
+	_ caddy.Provisioner = (*App)(nil)
+)
@@ -0,0 +1,167 @@
+package caddypsl
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"strings"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+	"golang.org/x/net/publicsuffix"
+)
+
+func init() {
+	caddy.RegisterModule(CookieSanitizer{})
+}
+
+// cookieJarCtxKey is the context key under which CookieSanitizer stores its *cookiejar.Jar,
+// so other handlers in the same request (e.g. a custom reverse-proxy transport) can retrieve
+// a PSL-aware jar instead of rolling their own eTLD+1 logic for cookie scoping.
+type cookieJarCtxKey struct{}
+
+// CookieJarFromContext returns the *cookiejar.Jar installed by CookieSanitizer, if any.
+func CookieJarFromContext(ctx context.Context) (*cookiejar.Jar, bool) {
+	jar, ok := ctx.Value(cookieJarCtxKey{}).(*cookiejar.Jar)
+	return jar, ok
+}
+
+// pslList adapts a pslLookup function to cookiejar.PublicSuffixList, the two-method
+// interface net/http/cookiejar needs to keep cookie scope decisions honoring eTLD+1
+// boundaries, which is the whole reason the PSL exists per that package's docs.
+type pslList struct {
+	lookup pslLookup
+}
+
+func (p pslList) PublicSuffix(domain string) string {
+	suffix, _ := p.lookup(domain)
+	return suffix
+}
+
+// String identifies this list implementation, as required by cookiejar.PublicSuffixList; it
+// has no bearing on cookie scoping.
+func (p pslList) String() string {
+	return "caddypsl"
+}
+
+// CookieSanitizer installs a PSL-aware *cookiejar.Jar into the request context (see
+// CookieJarFromContext), and optionally sanitizes the `Set-Cookie` headers of the response
+// so an upstream/backend can't set a cookie that's scoped to a public suffix, such as
+// `Domain=co.uk` or `Domain=blogspot.com`, which would otherwise be visible to every site
+// under that suffix.
+type CookieSanitizer struct {
+	// OnViolation controls what happens when a `Set-Cookie` response header's `Domain`
+	// attribute is itself a public suffix: "strip" (default) removes the `Domain`
+	// attribute so the cookie falls back to host-only scope, and "reject" drops the
+	// `Set-Cookie` header entirely.
+	OnViolation string `json:"on_violation,omitempty"`
+
+	lookup pslLookup
+}
+
+// CaddyModule returns the Caddy module information.
+func (CookieSanitizer) CaddyModule() caddy.ModuleInfo {
+	return caddy.ModuleInfo{
+		ID:  "http.handlers.psl_cookies",
+		New: func() caddy.Module { return new(CookieSanitizer) },
+	}
+}
+
+// Provision sets up the handler.
+func (cs *CookieSanitizer) Provision(ctx caddy.Context) error {
+	switch cs.OnViolation {
+	case "":
+		cs.OnViolation = "strip"
+	case "strip", "reject":
+	default:
+		return fmt.Errorf("on_violation must be 'strip' or 'reject', got %q", cs.OnViolation)
+	}
+
+	cs.lookup = publicsuffix.PublicSuffix
+	if app, err := ctx.App("psl"); err == nil {
+		cs.lookup = app.(*App).Lookup
+	}
+
+	return nil
+}
+
+func (cs *CookieSanitizer) ServeHTTP(w http.ResponseWriter, r *http.Request, next caddyhttp.Handler) error {
+	jar, err := cookiejar.New(&cookiejar.Options{PublicSuffixList: pslList{lookup: cs.lookup}})
+	if err != nil {
+		return err
+	}
+	r = r.WithContext(context.WithValue(r.Context(), cookieJarCtxKey{}, jar))
+
+	sw := &cookieSanitizingWriter{ResponseWriter: w, cs: cs}
+	return next.ServeHTTP(sw, r)
+}
+
+// cookieSanitizingWriter intercepts the response just before headers are sent so it can
+// sanitize any `Set-Cookie` headers the next handler (e.g. reverse_proxy, passing through an
+// upstream's response) set.
+type cookieSanitizingWriter struct {
+	http.ResponseWriter
+	cs          *CookieSanitizer
+	wroteHeader bool
+}
+
+func (w *cookieSanitizingWriter) WriteHeader(statusCode int) {
+	if !w.wroteHeader {
+		w.cs.sanitize(w.Header())
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *cookieSanitizingWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// sanitize rewrites header's Set-Cookie values, removing or stripping the Domain attribute
+// of any cookie whose Domain is itself a full match in the PSL (ICANN or privately-managed),
+// e.g. "co.uk" or "blogspot.com".
+func (cs *CookieSanitizer) sanitize(header http.Header) {
+	setCookies := header["Set-Cookie"]
+	if len(setCookies) == 0 {
+		return
+	}
+
+	resp := http.Response{Header: http.Header{"Set-Cookie": setCookies}}
+	cookies := resp.Cookies()
+
+	sanitized := make([]string, 0, len(cookies))
+	for _, c := range cookies {
+		if cs.violatesPublicSuffix(c.Domain) {
+			if cs.OnViolation == "reject" {
+				continue
+			}
+			c.Domain = ""
+		}
+		sanitized = append(sanitized, c.String())
+	}
+
+	header["Set-Cookie"] = sanitized
+}
+
+// violatesPublicSuffix reports whether domain (a cookie's raw Domain attribute) is itself a
+// complete entry on the PSL, rather than a registrable domain under one. Set-Cookie commonly
+// writes this attribute with a leading dot (e.g. "Domain=.co.uk"), which net/http's cookie
+// parser preserves verbatim, so that has to be stripped before comparing against the PSL.
+func (cs *CookieSanitizer) violatesPublicSuffix(domain string) bool {
+	domain = strings.TrimPrefix(domain, ".")
+	if domain == "" {
+		return false
+	}
+	suffix, _ := cs.lookup(domain)
+	return suffix == domain
+}
+
+// Interface guards
+var (
+	_ caddy.Provisioner           = (*CookieSanitizer)(nil)
+	_ caddyhttp.MiddlewareHandler = (*CookieSanitizer)(nil)
+)
@@ -0,0 +1,91 @@
+package caddypsl
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"testing"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// TestPSLListImplementsPublicSuffixList is a regression test for a bug where pslList only
+// implemented PublicSuffix, not the String method cookiejar.PublicSuffixList also requires,
+// so the package failed to build at all.
+func TestPSLListImplementsPublicSuffixList(t *testing.T) {
+	if _, err := cookiejar.New(&cookiejar.Options{PublicSuffixList: pslList{lookup: publicsuffix.PublicSuffix}}); err != nil {
+		t.Fatalf("cookiejar.New: %v", err)
+	}
+}
+
+// TestCookieSanitizerViolatesPublicSuffix is a regression test for a bug where a leading dot
+// on a cookie's Domain attribute - the extremely common "Domain=.co.uk" form - wasn't
+// stripped before comparing against the PSL, so it was never treated as a violation.
+func TestCookieSanitizerViolatesPublicSuffix(t *testing.T) {
+	cs := &CookieSanitizer{lookup: publicsuffix.PublicSuffix}
+
+	cases := []struct {
+		domain string
+		want   bool
+	}{
+		{"co.uk", true},
+		{".co.uk", true},
+		{"blogspot.com", true},
+		{".blogspot.com", true},
+		{"example.com", false},
+		{".example.com", false},
+		{"", false},
+	}
+
+	for _, c := range cases {
+		if got := cs.violatesPublicSuffix(c.domain); got != c.want {
+			t.Errorf("violatesPublicSuffix(%q) = %v, want %v", c.domain, got, c.want)
+		}
+	}
+}
+
+// TestCookieSanitizerSanitize exercises the full Set-Cookie rewriting path.
+func TestCookieSanitizerSanitize(t *testing.T) {
+	t.Run("strip", func(t *testing.T) {
+		cs := &CookieSanitizer{OnViolation: "strip", lookup: publicsuffix.PublicSuffix}
+		header := http.Header{"Set-Cookie": []string{"sid=x; Domain=.co.uk; Path=/", "sid2=y; Domain=.example.com"}}
+
+		cs.sanitize(header)
+
+		got := header["Set-Cookie"]
+		if len(got) != 2 {
+			t.Fatalf("got %d Set-Cookie headers, want 2: %v", len(got), got)
+		}
+		if contains(got[0], "Domain=") {
+			t.Errorf("expected Domain attribute to be stripped from public-suffix cookie, got %q", got[0])
+		}
+		if !contains(got[1], "Domain=") {
+			t.Errorf("expected Domain attribute to survive on a normal registered-domain cookie, got %q", got[1])
+		}
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		cs := &CookieSanitizer{OnViolation: "reject", lookup: publicsuffix.PublicSuffix}
+		header := http.Header{"Set-Cookie": []string{"sid=x; Domain=.co.uk", "sid2=y; Domain=.example.com"}}
+
+		cs.sanitize(header)
+
+		got := header["Set-Cookie"]
+		if len(got) != 1 {
+			t.Fatalf("got %d Set-Cookie headers, want 1: %v", len(got), got)
+		}
+		if !contains(got[0], "sid2=y") {
+			t.Errorf("expected the non-violating cookie to survive, got %v", got)
+		}
+	})
+}
+
+func contains(s, substr string) bool {
+	return len(s) >= len(substr) && (func() bool {
+		for i := 0; i+len(substr) <= len(s); i++ {
+			if s[i:i+len(substr)] == substr {
+				return true
+			}
+		}
+		return false
+	})()
+}
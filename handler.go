@@ -11,6 +11,11 @@ import (
 	"golang.org/x/net/publicsuffix"
 )
 
+// pslLookup matches the shape of publicsuffix.PublicSuffix, letting Handler (and the other
+// modules in this package) swap in the live-updating list from App without changing any of
+// the classification logic below.
+type pslLookup func(domain string) (suffix string, icann bool)
+
 func init() {
 	caddy.RegisterModule(Handler{})
 	httpcaddyfile.RegisterHandlerDirective("psl", parseCaddyfile)
@@ -25,6 +30,13 @@ func init() {
 // `qs.foo` would refer to the value `example.com`.
 // - **`header.*`** gets a value from the header with the named field, e.g. for a header `Host: example.com:1234`,
 // `header.Host` refers to the value `example.com`.
+// - **`sni.*`** gets the TLS ClientHello's ServerName, i.e. the SNI hostname (same for every key, since there's
+// only one SNI value per connection; e.g. `sni.is_icann`).
+// - **`cookie.<name>`** gets the value of the named cookie.
+// - **`path.<n>`** gets the Nth `/`-separated segment of the request path (1-indexed), useful for path-based
+// multi-tenant routing, e.g. for a path `/example.com/widgets`, `path.1` refers to `example.com`.
+// - **`var.<name>`** gets the named value already stored on the request's Caddy vars, so upstream handlers
+// can feed values into these placeholders.
 //
 // For all input values, ports are ignored automatically.
 //
@@ -51,6 +63,15 @@ func init() {
 // only returns a value if the suffix is an ICANN ending. In other words, it returns the registered domain
 // only if `is_icann` is true.
 //
+// - **`.manager`** returns `"icann"`, `"private"`, or `"none"`, classifying who manages the matching
+// suffix: an ICANN-delegated registry, a private operator (like `blogspot.com`), or nobody at all because
+// the input isn't on the PSL (e.g. `there.is.no.such-tld`, or a bare `cromulent`).
+//
+// - **`.label_count`** returns the number of dot-separated labels in the input, e.g. 3 for `sub.example.com`.
+//
+// - **`.subdomain`** returns everything to the left of the `.registered_domain`, or an empty string if the
+// input is already the registered domain (or no registered domain could be determined).
+//
 // Concatenate any of the placeholder prefixes with any of the placeholder endings to use the placeholder.
 //
 // Examples:
@@ -59,7 +80,12 @@ func init() {
 // - `{header.Host.registered_domain}` returns the registered domain of the value in the `Host` header field.
 // - `{header.Host.public_registered_domain}` is the same as the previous, but only returns a non-empty value if
 // the domain suffix is a public/ICANN-managed ending.
-type Handler struct{}
+//
+// The standalone placeholder `{psl.version}` returns an opaque identifier for the currently-loaded
+// list snapshot, if the `psl` app (see App) is configured; otherwise it's empty.
+type Handler struct {
+	app *App
+}
 
 // CaddyModule returns the Caddy module information.
 func (Handler) CaddyModule() caddy.ModuleInfo {
@@ -69,35 +95,76 @@ func (Handler) CaddyModule() caddy.ModuleInfo {
 	}
 }
 
-func (Handler) ServeHTTP(w http.ResponseWriter, r *http.Request, next caddyhttp.Handler) error {
+// Provision sets up the handler, wiring it up to the `psl` app if one is configured so
+// placeholders reflect the live-updating list instead of the PSL compiled in at build time.
+func (h *Handler) Provision(ctx caddy.Context) error {
+	app, err := ctx.App("psl")
+	if err == nil {
+		h.app = app.(*App)
+	}
+	return nil
+}
+
+// parseCaddyfile sets up the handler from Caddyfile tokens. Handler has no configurable
+// fields, so the directive takes no arguments or block; it's just:
+//
+//	psl
+func parseCaddyfile(h httpcaddyfile.Helper) (caddyhttp.MiddlewareHandler, error) {
+	if !h.Next() {
+		return nil, h.ArgErr()
+	}
+	if h.NextArg() {
+		return nil, h.ArgErr()
+	}
+	return new(Handler), nil
+}
+
+// lookup returns the live list's Lookup method if the psl app is configured, or else the
+// PSL compiled into golang.org/x/net/publicsuffix.
+func (h Handler) lookup() pslLookup {
+	if h.app != nil {
+		return h.app.Lookup
+	}
+	return publicsuffix.PublicSuffix
+}
+
+func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request, next caddyhttp.Handler) error {
 	repl := r.Context().Value(caddy.ReplacerCtxKey).(*caddy.Replacer)
+	lookup := h.lookup()
 
 	repl.Map(func(key string) (any, bool) {
+		if key == "psl.version" {
+			if h.app != nil {
+				return h.app.Version(), true
+			}
+			return "", true
+		}
+
 		if !strings.HasSuffix(key, ".registered_domain") &&
 			!strings.HasSuffix(key, ".public_registered_domain") &&
 			!strings.HasSuffix(key, ".public_suffix") &&
 			!strings.HasSuffix(key, ".domain_suffix") &&
-			!strings.HasSuffix(key, ".is_icann") {
+			!strings.HasSuffix(key, ".is_icann") &&
+			!strings.HasSuffix(key, ".manager") &&
+			!strings.HasSuffix(key, ".label_count") &&
+			!strings.HasSuffix(key, ".subdomain") {
 			return nil, false
 		}
 
 		parts := strings.Split(key, ".")
-		if len(parts) < 3 {
+		if len(parts) < 2 {
 			return nil, false
 		}
 
-		var host string
+		ending := parts[len(parts)-1]
+		arg := strings.Join(parts[1:len(parts)-1], ".")
 
-		switch parts[0] {
-		case "qs":
-			host = r.URL.Query().Get(parts[1])
-		case "header":
-			if strings.ToLower(parts[1]) == "host" {
-				host = r.Host
-			} else {
-				host = r.Header.Get(parts[1])
-			}
-		default:
+		source, ok := sources[parts[0]]
+		if !ok {
+			return nil, false
+		}
+		host, ok := source(r, arg)
+		if !ok {
 			return nil, false
 		}
 
@@ -113,12 +180,12 @@ func (Handler) ServeHTTP(w http.ResponseWriter, r *http.Request, next caddyhttp.
 		// blogspot.com. And `EffectiveTLDPlusOne()` ignores the "icann"
 		// flag, so I have to roll my own logic here and stick to my
 		// own placeholder names that I think make more sense.
-		switch parts[2] {
+		switch ending {
 		case "registered_domain":
-			return registeredDomain(domain), true
+			return registeredDomain(domain, lookup), true
 
 		case "public_registered_domain":
-			eTLD, icann := publicsuffix.PublicSuffix(domain)
+			eTLD, icann := lookup(domain)
 			if icann {
 				return suffixPlusOne(domain, eTLD), true
 			}
@@ -128,15 +195,28 @@ func (Handler) ServeHTTP(w http.ResponseWriter, r *http.Request, next caddyhttp.
 			// this placeholder should only return a value if the domain suffix is ICANN-managed,
 			// i.e. is a "public" eTLD someone can purchase a domain for from a registrar; we need
 			// to trim labels off the domain until we find the ICANN eTLD
-			return icannSuffix(domain), true
+			return icannSuffix(domain, lookup), true
 
 		case "domain_suffix":
-			eTLD, _ := publicsuffix.PublicSuffix(domain)
+			eTLD, _ := lookup(domain)
 			return eTLD, true
 
 		case "is_icann":
-			_, icann := publicsuffix.PublicSuffix(domain)
+			_, icann := lookup(domain)
 			return icann, true
+
+		case "manager":
+			return classifyManager(domain, lookup, "none"), true
+
+		case "label_count":
+			return strings.Count(domain, ".") + 1, true
+
+		case "subdomain":
+			rd := registeredDomain(domain, lookup)
+			if rd == "" || rd == domain {
+				return "", true
+			}
+			return strings.TrimSuffix(domain, "."+rd), true
 		}
 
 		return nil, false
@@ -146,8 +226,8 @@ func (Handler) ServeHTTP(w http.ResponseWriter, r *http.Request, next caddyhttp.
 }
 
 // registeredDomain returns the eTLD+1, where the eTLD must be ICANN-managed.
-func registeredDomain(domain string) string {
-	publicSuffix := icannSuffix(domain)
+func registeredDomain(domain string, lookup pslLookup) string {
+	publicSuffix := icannSuffix(domain, lookup)
 	return suffixPlusOne(domain, publicSuffix)
 }
 
@@ -164,23 +244,49 @@ func suffixPlusOne(domain, suffix string) string {
 }
 
 // icannSuffix returns the eTLD that is ICANN-managed; i.e. "foo.blogspot.com" -> "com", even
-// though "blogspot.com" is on the PSL.
-func icannSuffix(domain string) string {
+// though "blogspot.com" is on the PSL. It returns "" if no label of domain is ICANN-managed,
+// e.g. for a bogus TLD like "there.is.no.such-tld".
+func icannSuffix(domain string, lookup pslLookup) string {
 	for {
-		eTLD, icann := publicsuffix.PublicSuffix(domain)
+		eTLD, icann := lookup(domain)
 		if icann {
 			return eTLD
 		}
-		// not an ICANN domain, so must be a privately-managed domain if there's a dot
-		if strings.IndexByte(eTLD, '.') >= 0 {
-			var ok bool
-			_, domain, ok = strings.Cut(eTLD, ".")
-			if !ok {
-				return ""
-			}
+		// not an ICANN domain; strip the leftmost label of domain (not of eTLD, which for an
+		// unmanaged TLD is just domain's own rightmost label with no dot, and would never
+		// shrink) and keep walking up until there's nothing left to strip.
+		i := strings.IndexByte(domain, '.')
+		if i < 0 {
+			return ""
 		}
+		domain = domain[i+1:]
+	}
+}
+
+// classifyManager reports who manages domain's suffix: an ICANN-delegated registry, a
+// private operator (like blogspot.com), or nobody, because domain isn't on the PSL at all
+// (e.g. "there.is.no.such-tld" or a bare "cromulent"). unmanagedLabel is returned for that
+// last case, since the `.manager` placeholder calls it "none" while MatchPublicSuffix's
+// `manager` matcher field calls it "unmanaged" - shared here so the two don't drift.
+//
+// lookup's returned suffix is the tell: for a domain with no PSL entry at all, it's always
+// just domain's rightmost label (no dot), no matter how many labels domain itself has. A
+// real private-managed suffix like "blogspot.com" is always multiple labels, since it's
+// necessarily a subdomain of some ICANN-delegated TLD.
+func classifyManager(domain string, lookup pslLookup, unmanagedLabel string) string {
+	suffix, icann := lookup(domain)
+	switch {
+	case icann:
+		return "icann"
+	case !strings.Contains(suffix, "."):
+		return unmanagedLabel
+	default:
+		return "private"
 	}
 }
 
 // Interface guards
-var _ caddyhttp.MiddlewareHandler = (*Handler)(nil)
+var (
+	_ caddy.Provisioner           = (*Handler)(nil)
+	_ caddyhttp.MiddlewareHandler = (*Handler)(nil)
+)
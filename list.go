@@ -0,0 +1,129 @@
+package caddypsl
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// pslTrie is a reverse-label trie of Public Suffix List rules, letting Lookup find the
+// longest matching rule for a domain without scanning the whole list linearly.
+type pslTrie struct {
+	root *pslNode
+}
+
+// pslNode is one label's worth of a PSL rule. Children are keyed by label, except for
+// wildcard rules (e.g. "*.ck"), whose wildcard label is stored under the key "*".
+type pslNode struct {
+	children  map[string]*pslNode
+	isRule    bool // true if a rule ends exactly at this node
+	icann     bool // true if the rule ending here is ICANN-managed
+	exception bool // true if the rule ending here is an exception rule (e.g. "!city.kobe.jp")
+}
+
+func newPSLTrie() *pslTrie {
+	return &pslTrie{root: &pslNode{children: make(map[string]*pslNode)}}
+}
+
+// insert adds a rule (given as labels in natural left-to-right order, e.g. ["city", "kobe", "jp"])
+// to the trie, tagging it as icann-managed or not, and as an exception rule or not.
+func (t *pslTrie) insert(labels []string, icann, exception bool) {
+	node := t.root
+	for i := len(labels) - 1; i >= 0; i-- {
+		label := labels[i]
+		child, ok := node.children[label]
+		if !ok {
+			child = &pslNode{children: make(map[string]*pslNode)}
+			node.children[label] = child
+		}
+		node = child
+	}
+	node.isRule = true
+	node.icann = icann
+	node.exception = exception
+}
+
+// Lookup returns the matching public suffix for domain and whether it is ICANN-managed,
+// using the standard PSL algorithm: the longest matching rule wins, and an exception rule
+// shortens the match by one label. If no rule matches, the implicit "*" rule applies and the
+// suffix is just the rightmost label.
+func (t *pslTrie) Lookup(domain string) (suffix string, icann bool) {
+	if domain == "" {
+		return "", false
+	}
+	labels := strings.Split(domain, ".")
+
+	node := t.root
+	matchLen := 0
+	var matchICANN, matchException bool
+	for i := len(labels) - 1; i >= 0; i-- {
+		child, ok := node.children[labels[i]]
+		if !ok {
+			child, ok = node.children["*"]
+			if !ok {
+				break
+			}
+		}
+		node = child
+		if node.isRule {
+			matchLen = len(labels) - i
+			matchICANN = node.icann
+			matchException = node.exception
+		}
+	}
+
+	if matchLen == 0 {
+		return labels[len(labels)-1], false
+	}
+	if matchException {
+		matchLen--
+	}
+	return strings.Join(labels[len(labels)-matchLen:], "."), matchICANN
+}
+
+// parsePSL reads the Public Suffix List text format from r and returns a trie of its rules.
+// Lines are either blank, a "//" comment (including the "===BEGIN ICANN DOMAINS===" /
+// "===END ICANN DOMAINS===" / "===BEGIN PRIVATE DOMAINS===" section markers), or a rule: a
+// normal rule like "co.uk", a wildcard rule like "*.ck", or an exception rule like
+// "!city.kobe.jp". If icannOnly is true, rules in the private-domains section are skipped.
+func parsePSL(r io.Reader, icannOnly bool) (*pslTrie, error) {
+	t := newPSLTrie()
+	icann := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "//") {
+			switch {
+			case strings.Contains(line, "===BEGIN ICANN DOMAINS==="):
+				icann = true
+			case strings.Contains(line, "===BEGIN PRIVATE DOMAINS==="):
+				icann = false
+			case strings.Contains(line, "===END ICANN DOMAINS==="),
+				strings.Contains(line, "===END PRIVATE DOMAINS==="):
+				icann = false
+			}
+			continue
+		}
+		if icannOnly && !icann {
+			continue
+		}
+
+		rule := line
+		exception := false
+		if strings.HasPrefix(rule, "!") {
+			exception = true
+			rule = rule[1:]
+		}
+
+		labels := strings.Split(rule, ".")
+		t.insert(labels, icann, exception)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
@@ -0,0 +1,70 @@
+package caddypsl
+
+import (
+	"strings"
+	"testing"
+)
+
+// testPSL is a small fixture covering the PSL rule kinds parsePSL and pslTrie must handle:
+// plain rules, a wildcard rule ("*.kobe.jp", "*.ck"), an exception rule ("!city.kobe.jp",
+// "!www.ck") - both taken from the real PSL, which documents them as its canonical examples -
+// and one privately-managed rule ("blogspot.com").
+const testPSL = `
+// ===BEGIN ICANN DOMAINS===
+com
+co.uk
+jp
+kobe.jp
+*.kobe.jp
+!city.kobe.jp
+*.ck
+!www.ck
+// ===END ICANN DOMAINS===
+
+// ===BEGIN PRIVATE DOMAINS===
+blogspot.com
+// ===END PRIVATE DOMAINS===
+`
+
+func TestParsePSLAndLookup(t *testing.T) {
+	tree, err := parsePSL(strings.NewReader(testPSL), false)
+	if err != nil {
+		t.Fatalf("parsePSL: %v", err)
+	}
+
+	cases := []struct {
+		domain    string
+		wantSfx   string
+		wantICANN bool
+	}{
+		{"example.com", "com", true},
+		{"foo.example.co.uk", "co.uk", true},
+		{"foo.blogspot.com", "blogspot.com", false},
+		{"city.kobe.jp", "kobe.jp", true},     // exception rule shortens the match by one label
+		{"foo.kobe.jp", "foo.kobe.jp", true},  // wildcard rule
+		{"foo.ck", "foo.ck", true},            // wildcard rule
+		{"www.ck", "ck", true},                // exception rule shortens the match by one label
+		{"nonexistent-tld", "nonexistent-tld", false},
+	}
+
+	for _, c := range cases {
+		gotSfx, gotICANN := tree.Lookup(c.domain)
+		if gotSfx != c.wantSfx || gotICANN != c.wantICANN {
+			t.Errorf("Lookup(%q) = (%q, %v), want (%q, %v)", c.domain, gotSfx, gotICANN, c.wantSfx, c.wantICANN)
+		}
+	}
+}
+
+func TestParsePSLICANNOnly(t *testing.T) {
+	tree, err := parsePSL(strings.NewReader(testPSL), true)
+	if err != nil {
+		t.Fatalf("parsePSL: %v", err)
+	}
+
+	// blogspot.com is a private-domains rule, so with icannOnly it shouldn't be loaded at
+	// all, and the lookup should fall back to the next-longest ICANN match, "com".
+	gotSfx, gotICANN := tree.Lookup("foo.blogspot.com")
+	if gotSfx != "com" || !gotICANN {
+		t.Errorf("Lookup(foo.blogspot.com) with icannOnly = (%q, %v), want (\"com\", true)", gotSfx, gotICANN)
+	}
+}
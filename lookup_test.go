@@ -0,0 +1,71 @@
+package caddypsl
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// TestIcannSuffixTerminates is a regression test for a bug where icannSuffix (and, through
+// it, registeredDomain) spun forever on any domain whose TLD isn't on the PSL, because the
+// loop's termination check looked at the returned suffix instead of the remaining domain -
+// and for an unmanaged TLD, publicsuffix.PublicSuffix always returns just the rightmost
+// label, which never shrinks. Since MatchPublicSuffix runs this on the raw, attacker-supplied
+// request Host before any handler runs, this was a trivial unauthenticated DoS.
+func TestIcannSuffixTerminates(t *testing.T) {
+	cases := []struct {
+		domain string
+		want   string
+	}{
+		{"sub.example.com", "com"},
+		{"foo.blogspot.com", "com"},
+		{"cromulent", ""},
+		{"there.is.no.such-tld", ""},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.domain, func(t *testing.T) {
+			result := make(chan string, 1)
+			go func() { result <- icannSuffix(c.domain, publicsuffix.PublicSuffix) }()
+
+			select {
+			case got := <-result:
+				if got != c.want {
+					t.Errorf("icannSuffix(%q) = %q, want %q", c.domain, got, c.want)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatalf("icannSuffix(%q) did not return within 2s (infinite loop regression)", c.domain)
+			}
+		})
+	}
+}
+
+func TestRegisteredDomainTerminates(t *testing.T) {
+	cases := []struct {
+		domain string
+		want   string
+	}{
+		{"sub.example.com", "example.com"},
+		{"foo.blogspot.com", "blogspot.com"},
+		{"there.is.no.such-tld", ""},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.domain, func(t *testing.T) {
+			result := make(chan string, 1)
+			go func() { result <- registeredDomain(c.domain, publicsuffix.PublicSuffix) }()
+
+			select {
+			case got := <-result:
+				if got != c.want {
+					t.Errorf("registeredDomain(%q) = %q, want %q", c.domain, got, c.want)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatalf("registeredDomain(%q) did not return within 2s (infinite loop regression)", c.domain)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package caddypsl
+
+import (
+	"testing"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// TestClassifyManager is a regression test for a bug where the unmanaged case was detected
+// by comparing the whole input domain against the returned suffix, which only worked when
+// the input itself was a single label. Real-world bogus hosts are usually multi-label (the
+// chunk0-4 request's own example, "there.is.no.such-tld", has four), and all of them were
+// misclassified as "private" instead of unmanaged.
+func TestClassifyManager(t *testing.T) {
+	cases := []struct {
+		domain string
+		want   string
+	}{
+		{"sub.example.com", "icann"},
+		{"foo.blogspot.com", "private"},
+		{"cromulent", "unmanaged"},
+		{"there.is.no.such-tld", "unmanaged"},
+	}
+
+	for _, c := range cases {
+		if got := classifyManager(c.domain, publicsuffix.PublicSuffix, "unmanaged"); got != c.want {
+			t.Errorf("classifyManager(%q) = %q, want %q", c.domain, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package caddypsl
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/caddyconfig/caddyfile"
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+	"golang.org/x/net/publicsuffix"
+)
+
+func init() {
+	caddy.RegisterModule(MatchPublicSuffix{})
+}
+
+// MatchPublicSuffix matches requests based on the Public Suffix List (PSL) classification
+// of a domain-like value taken from the request, typically the Host. It shares the same
+// classification logic as the `psl` placeholders implemented by Handler in this module, so
+// matching stays consistent with whatever placeholders report.
+//
+// An empty MatchPublicSuffix matches no requests; configure at least one of RegisteredDomain,
+// PublicSuffix, IsICANN, or Manager.
+type MatchPublicSuffix struct {
+	// Source is where to get the domain-like value to classify. It may be "host" (the
+	// default, using the request's Host field with any port stripped), or a placeholder-style
+	// source prefix understood by Handler, such as "qs.<key>" or "header.<name>".
+	Source string `json:"source,omitempty"`
+
+	// RegisteredDomain is a list of eTLD+1 values to match exactly, e.g. "example.co.uk".
+	RegisteredDomain []string `json:"registered_domain,omitempty"`
+
+	// PublicSuffix is a list of eTLDs to match exactly, e.g. "co.uk".
+	PublicSuffix []string `json:"public_suffix,omitempty"`
+
+	// IsICANN, if set, requires the domain's suffix to be (or not be) ICANN-managed.
+	IsICANN *bool `json:"is_icann,omitempty"`
+
+	// Manager, if set, requires the domain to be classified as "icann", "private", or
+	// "unmanaged" (no PSL entry at all, so only the implicit single-label rule applies).
+	Manager string `json:"manager,omitempty"`
+
+	lookup pslLookup
+}
+
+// CaddyModule returns the Caddy module information.
+func (MatchPublicSuffix) CaddyModule() caddy.ModuleInfo {
+	return caddy.ModuleInfo{
+		ID:  "http.matchers.public_suffix",
+		New: func() caddy.Module { return new(MatchPublicSuffix) },
+	}
+}
+
+// Provision sets up the matcher.
+func (m *MatchPublicSuffix) Provision(ctx caddy.Context) error {
+	switch m.Manager {
+	case "", "icann", "private", "unmanaged":
+	default:
+		return fmt.Errorf("manager must be 'icann', 'private', or 'unmanaged', got %q", m.Manager)
+	}
+
+	m.lookup = publicsuffix.PublicSuffix
+	if app, err := ctx.App("psl"); err == nil {
+		m.lookup = app.(*App).Lookup
+	}
+
+	return nil
+}
+
+// Match returns true if r matches m.
+func (m MatchPublicSuffix) Match(r *http.Request) bool {
+	if len(m.RegisteredDomain) == 0 && len(m.PublicSuffix) == 0 && m.IsICANN == nil && m.Manager == "" {
+		return false
+	}
+
+	host := m.source(r)
+
+	domain, _, err := net.SplitHostPort(host)
+	if err != nil {
+		domain = host
+	}
+
+	if len(m.RegisteredDomain) > 0 && !matchesAny(registeredDomain(domain, m.lookup), m.RegisteredDomain) {
+		return false
+	}
+
+	if len(m.PublicSuffix) > 0 && !matchesAny(icannSuffix(domain, m.lookup), m.PublicSuffix) {
+		return false
+	}
+
+	if m.IsICANN != nil {
+		_, icann := m.lookup(domain)
+		if icann != *m.IsICANN {
+			return false
+		}
+	}
+
+	if m.Manager != "" && classifyManager(domain, m.lookup, "unmanaged") != m.Manager {
+		return false
+	}
+
+	return true
+}
+
+// source extracts the domain-like value from r according to m.Source, which is either "host"
+// (the default) or one of the placeholder source prefixes in the sources registry, e.g.
+// "qs.key", "header.name", "cookie.name", "path.n", "var.name", or "sni".
+func (m MatchPublicSuffix) source(r *http.Request) string {
+	if m.Source == "" || m.Source == "host" {
+		return r.Host
+	}
+
+	parts := strings.SplitN(m.Source, ".", 2)
+	source, ok := sources[parts[0]]
+	if !ok {
+		return ""
+	}
+	var arg string
+	if len(parts) == 2 {
+		arg = parts[1]
+	}
+	value, _ := source(r, arg)
+	return value
+}
+
+func matchesAny(value string, candidates []string) bool {
+	for _, c := range candidates {
+		if value == c {
+			return true
+		}
+	}
+	return false
+}
+
+// UnmarshalCaddyfile implements caddyfile.Unmarshaler. Syntax:
+//
+//	public_suffix {
+//	    registered_domain <domains...>
+//	    public_suffix <suffixes...>
+//	    is_icann <true|false>
+//	    manager <icann|private|unmanaged>
+//	    source <host|qs.key|header.name>
+//	}
+//
+// As a shortcut, `public_suffix registered_domain <domains...>` is also accepted on a single line.
+func (m *MatchPublicSuffix) UnmarshalCaddyfile(d *caddyfile.Dispenser) error {
+	for d.Next() {
+		args := d.RemainingArgs()
+		if len(args) > 0 {
+			if err := m.unmarshalField(d, args[0], args[1:]); err != nil {
+				return err
+			}
+		}
+		for nesting := d.Nesting(); d.NextBlock(nesting); {
+			if err := m.unmarshalField(d, d.Val(), d.RemainingArgs()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (m *MatchPublicSuffix) unmarshalField(d *caddyfile.Dispenser, field string, args []string) error {
+	switch field {
+	case "registered_domain":
+		m.RegisteredDomain = append(m.RegisteredDomain, args...)
+	case "public_suffix":
+		m.PublicSuffix = append(m.PublicSuffix, args...)
+	case "is_icann":
+		if len(args) != 1 {
+			return d.ArgErr()
+		}
+		isICANN, err := strconv.ParseBool(args[0])
+		if err != nil {
+			return d.Errf("invalid boolean value %q for is_icann: %v", args[0], err)
+		}
+		m.IsICANN = &isICANN
+	case "manager":
+		if len(args) != 1 {
+			return d.ArgErr()
+		}
+		m.Manager = args[0]
+	case "source":
+		if len(args) != 1 {
+			return d.ArgErr()
+		}
+		m.Source = args[0]
+	default:
+		return d.Errf("unrecognized subdirective: %s", field)
+	}
+	return nil
+}
+
+// Interface guards
+var (
+	_ caddy.Provisioner        = (*MatchPublicSuffix)(nil)
+	_ caddyhttp.RequestMatcher = (*MatchPublicSuffix)(nil)
+	_ caddyfile.Unmarshaler    = (*MatchPublicSuffix)(nil)
+)
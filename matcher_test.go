@@ -0,0 +1,74 @@
+package caddypsl
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/caddyserver/caddy/v2/caddyconfig/caddyfile"
+)
+
+func TestMatchPublicSuffixMatch(t *testing.T) {
+	icann := true
+	notICANN := false
+
+	cases := []struct {
+		name string
+		m    MatchPublicSuffix
+		host string
+		want bool
+	}{
+		{"registered domain match", MatchPublicSuffix{RegisteredDomain: []string{"example.co.uk"}}, "foo.example.co.uk", true},
+		{"registered domain mismatch", MatchPublicSuffix{RegisteredDomain: []string{"example.co.uk"}}, "foo.example.com", false},
+		{"public suffix match", MatchPublicSuffix{PublicSuffix: []string{"co.uk"}}, "example.co.uk", true},
+		{"is_icann true matches icann", MatchPublicSuffix{IsICANN: &icann}, "example.com", true},
+		{"is_icann true excludes private", MatchPublicSuffix{IsICANN: &icann}, "foo.blogspot.com", false},
+		{"is_icann false matches private", MatchPublicSuffix{IsICANN: &notICANN}, "foo.blogspot.com", true},
+		{"manager unmanaged", MatchPublicSuffix{Manager: "unmanaged"}, "there.is.no.such-tld", true},
+		{"manager private", MatchPublicSuffix{Manager: "private"}, "foo.blogspot.com", true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			m := c.m
+			m.lookup = testLookup
+			r := httptest.NewRequest("GET", "http://"+c.host+"/", nil)
+			if got := m.Match(r); got != c.want {
+				t.Errorf("Match(%q) = %v, want %v", c.host, got, c.want)
+			}
+		})
+	}
+}
+
+func TestMatchPublicSuffixUnmarshalCaddyfile(t *testing.T) {
+	d := caddyfile.NewTestDispenser(`public_suffix {
+		is_icann true
+	}`)
+	var m MatchPublicSuffix
+	if err := m.UnmarshalCaddyfile(d); err != nil {
+		t.Fatalf("UnmarshalCaddyfile: %v", err)
+	}
+	if m.IsICANN == nil || !*m.IsICANN {
+		t.Errorf("IsICANN = %v, want true", m.IsICANN)
+	}
+}
+
+// TestMatchPublicSuffixUnmarshalCaddyfileInvalidIsICANN is a regression test for a bug where
+// an invalid is_icann value was silently treated as false instead of being rejected.
+func TestMatchPublicSuffixUnmarshalCaddyfileInvalidIsICANN(t *testing.T) {
+	d := caddyfile.NewTestDispenser(`public_suffix {
+		is_icann bogus
+	}`)
+	var m MatchPublicSuffix
+	if err := m.UnmarshalCaddyfile(d); err == nil {
+		t.Fatal("expected an error for an invalid is_icann value, got nil")
+	}
+}
+
+func testLookup(domain string) (string, bool) {
+	tree, err := parsePSL(strings.NewReader(testPSL), false)
+	if err != nil {
+		panic(err)
+	}
+	return tree.Lookup(domain)
+}
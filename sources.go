@@ -0,0 +1,57 @@
+package caddypsl
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+)
+
+// sourceFunc extracts a domain-like value from r, given the argument that followed the
+// source name in the placeholder key (e.g. for "header.Host.is_icann", arg is "Host"; for
+// "sni.is_icann", which takes no argument, arg is ""). ok is false if the source doesn't
+// apply to this request at all (as opposed to applying but being empty).
+type sourceFunc func(r *http.Request, arg string) (value string, ok bool)
+
+// sources is the registry of placeholder source prefixes Handler understands. Third-party
+// modules can add to it at init time to make their own sources available to `psl` placeholders.
+var sources = map[string]sourceFunc{
+	"qs": func(r *http.Request, arg string) (string, bool) {
+		return r.URL.Query().Get(arg), true
+	},
+	"header": func(r *http.Request, arg string) (string, bool) {
+		if strings.EqualFold(arg, "host") {
+			return r.Host, true
+		}
+		return r.Header.Get(arg), true
+	},
+	"sni": func(r *http.Request, _ string) (string, bool) {
+		if r.TLS == nil {
+			return "", false
+		}
+		return r.TLS.ServerName, true
+	},
+	"cookie": func(r *http.Request, arg string) (string, bool) {
+		c, err := r.Cookie(arg)
+		if err != nil {
+			return "", false
+		}
+		return c.Value, true
+	},
+	"path": func(r *http.Request, arg string) (string, bool) {
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 1 {
+			return "", false
+		}
+		segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		if n > len(segments) {
+			return "", false
+		}
+		return segments[n-1], true
+	},
+	"var": func(r *http.Request, arg string) (string, bool) {
+		v, ok := caddyhttp.GetVar(r.Context(), arg).(string)
+		return v, ok
+	},
+}
@@ -0,0 +1,57 @@
+package caddypsl
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSources(t *testing.T) {
+	r := httptest.NewRequest("GET", "http://host.example/foo/bar?domain=qs.example.com", nil)
+	r.Header.Set("X-Domain", "header.example.com")
+	r.AddCookie(&http.Cookie{Name: "sess", Value: "cookie.example.com"})
+
+	cases := []struct {
+		name   string
+		arg    string
+		want   string
+		wantOK bool
+	}{
+		{"qs", "domain", "qs.example.com", true},
+		{"header", "X-Domain", "header.example.com", true},
+		{"header", "Host", "host.example", true},
+		{"cookie", "sess", "cookie.example.com", true},
+		{"cookie", "missing", "", false},
+		{"path", "1", "foo", true},
+		{"path", "2", "bar", true},
+		{"path", "3", "", false},
+		{"path", "not-a-number", "", false},
+	}
+
+	for _, c := range cases {
+		source, ok := sources[c.name]
+		if !ok {
+			t.Fatalf("no such source %q", c.name)
+		}
+		got, gotOK := source(r, c.arg)
+		if got != c.want || gotOK != c.wantOK {
+			t.Errorf("sources[%q](%q) = (%q, %v), want (%q, %v)", c.name, c.arg, got, gotOK, c.want, c.wantOK)
+		}
+	}
+}
+
+func TestSNISource(t *testing.T) {
+	source := sources["sni"]
+
+	r := httptest.NewRequest("GET", "http://host.example/", nil)
+	if _, ok := source(r, ""); ok {
+		t.Error("sni source should not apply to a non-TLS request")
+	}
+
+	r.TLS = &tls.ConnectionState{ServerName: "sni.example.com"}
+	got, ok := source(r, "")
+	if !ok || got != "sni.example.com" {
+		t.Errorf("sni source = (%q, %v), want (\"sni.example.com\", true)", got, ok)
+	}
+}